@@ -0,0 +1,138 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// PartialCommitError is returned by DoAllContext when some, but not all, of the transactions were
+// committed. The transactions for dbs[:Committed] were committed, the commit for dbs[Committed]
+// failed and every later transaction was rolled back.
+type PartialCommitError struct {
+	Committed int
+	Err       error
+}
+
+func (e *PartialCommitError) Error() string {
+	return fmt.Sprintf("transact: partial commit: %d transaction(s) committed: %v", e.Committed, e.Err)
+}
+
+// Unwrap returns the commit error, which wraps ErrCommit, joined with any rollback errors.
+func (e *PartialCommitError) Unwrap() error {
+	return e.Err
+}
+
+// DoAllContext executes the given txFunc inside of a new transaction on every db, with txs[i]
+// started on dbs[i]. Any error or panic, or ctx being done, rolls back every transaction. On
+// success the transactions are committed in order; because this is only a best-effort two-phase
+// commit a failed commit rolls back the remaining transactions and, if earlier ones were already
+// committed, returns a *PartialCommitError describing the state left behind. A single TxReport
+// covers every transaction.
+func DoAllContext[D DB](ctx context.Context, dbs []D, txFunc func(txs []*sql.Tx) error, opts ...Option) (err error) {
+	o := newOptions(opts)
+	if o.err != nil {
+		return o.err
+	}
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	r := newReporter(o)
+	txs := make([]*sql.Tx, 0, len(dbs))
+	for _, db := range dbs {
+		tx, bErr := db.BeginTx(ctx, o.txOptions)
+		if bErr != nil {
+			err = errors.Join(bErr, rollbackAll(txs))
+			r.begun()
+			r.finished(OutcomeBeginFailed, err)
+			r.send()
+			return
+		}
+		txs = append(txs, tx)
+	}
+	r.begun()
+	o.logDirtyRead()
+
+	cbs := make([]*callbacks, len(txs))
+	for i, tx := range txs {
+		cbs[i] = register(tx)
+	}
+
+	defer r.send()
+	defer func() {
+		for _, c := range cbs {
+			c.unregister()
+		}
+		r.ran()
+
+		if pErr := recover(); pErr != nil {
+			r.finished(OutcomePanicked, errors.Join(fmt.Errorf("panic: %v", pErr), rollbackAll(txs)))
+			runAllAfterRollback(cbs, o.hooks)
+			panic(pErr)
+		}
+
+		if cErr := canceled(ctx); cErr != nil {
+			err = errors.Join(cErr, err)
+		}
+
+		if err != nil {
+			if rErr := rollbackAll(txs); rErr != nil {
+				err = errors.Join(err, rErr)
+			}
+			r.finished(OutcomeRolledBack, err)
+			runAllAfterRollback(cbs, o.hooks)
+			return
+		}
+
+		committed := len(txs)
+		for i, tx := range txs {
+			if cErr := tx.Commit(); cErr != nil {
+				committed = i
+				err = fmt.Errorf("%w: %w", ErrCommit, cErr)
+				if rErr := rollbackAll(txs[i+1:]); rErr != nil {
+					err = errors.Join(err, rErr)
+				}
+				if committed > 0 {
+					err = &PartialCommitError{Committed: committed, Err: err}
+				}
+				break
+			}
+		}
+		if err != nil {
+			r.finished(OutcomeCommitFailed, err)
+		} else {
+			r.finished(OutcomeCommitted, nil)
+		}
+
+		for _, c := range cbs[:committed] {
+			c.runAfterCommit(o.hooks)
+		}
+		runAllAfterRollback(cbs[committed:], o.hooks)
+	}()
+
+	return txFunc(txs)
+}
+
+// DoAll executes the given txFunc inside of a new transaction on every db. See DoAllContext for
+// details.
+func DoAll[D DB](dbs []D, txFunc func(txs []*sql.Tx) error, opts ...Option) (err error) {
+	return DoAllContext(context.Background(), dbs, txFunc, opts...)
+}
+
+// rollbackAll rolls back every tx, joining any errors.
+func rollbackAll(txs []*sql.Tx) error {
+	var errs []error
+	for _, tx := range txs {
+		errs = append(errs, rollback(tx))
+	}
+	return errors.Join(errs...)
+}
+
+// runAllAfterRollback runs the queued AfterRollback callbacks of every transaction.
+func runAllAfterRollback(cbs []*callbacks, hooks Hooks) {
+	for _, c := range cbs {
+		c.runAfterRollback(hooks)
+	}
+}
@@ -0,0 +1,168 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoAllContext(t *testing.T) {
+	testCases := []struct {
+		label  string
+		txFunc func(txs []*sql.Tx) error
+		check  func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error)
+	}{
+		{
+			label: "every transaction is committed on any success",
+			txFunc: func(txs []*sql.Tx) error {
+				if len(txs) != 2 {
+					return assert.AnError
+				}
+				return nil
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				for _, mock := range mocks {
+					mock.ExpectBegin()
+					mock.ExpectCommit()
+				}
+				require.NoError(t, DoAllContext(context.Background(), dbs, txFunc))
+			},
+		}, {
+			label: "every transaction is rolled back on any error",
+			txFunc: func(txs []*sql.Tx) error {
+				return assert.AnError
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				for _, mock := range mocks {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				}
+				require.EqualError(t, DoAllContext(context.Background(), dbs, txFunc), assert.AnError.Error())
+			},
+		}, {
+			label: "every transaction is rolled back and a panic is raised on any panic",
+			txFunc: func(txs []*sql.Tx) error {
+				panic(assert.AnError)
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				for _, mock := range mocks {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				}
+				require.Panics(t, func() { DoAllContext(context.Background(), dbs, txFunc) })
+			},
+		}, {
+			label: "started transactions are rolled back when a later begin fails",
+			txFunc: func(txs []*sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				mocks[0].ExpectBegin()
+				mocks[0].ExpectRollback()
+				mocks[1].ExpectBegin().WillReturnError(assert.AnError)
+				require.ErrorIs(t, DoAllContext(context.Background(), dbs, txFunc), assert.AnError)
+			},
+		}, {
+			label: "the remaining transactions are rolled back when the first commit fails",
+			txFunc: func(txs []*sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				mocks[0].ExpectBegin()
+				mocks[0].ExpectCommit().WillReturnError(assert.AnError)
+				mocks[1].ExpectBegin()
+				mocks[1].ExpectRollback()
+				err := DoAllContext(context.Background(), dbs, txFunc)
+				require.ErrorIs(t, err, ErrCommit)
+				var pErr *PartialCommitError
+				assert.False(t, errors.As(err, &pErr))
+			},
+		}, {
+			label: "a partial commit error is returned when a later commit fails",
+			txFunc: func(txs []*sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				mocks[0].ExpectBegin()
+				mocks[0].ExpectCommit()
+				mocks[1].ExpectBegin()
+				mocks[1].ExpectCommit().WillReturnError(assert.AnError)
+				err := DoAllContext(context.Background(), dbs, txFunc)
+				require.ErrorIs(t, err, ErrCommit)
+				require.ErrorIs(t, err, assert.AnError)
+				var pErr *PartialCommitError
+				require.True(t, errors.As(err, &pErr))
+				assert.Equal(t, 1, pErr.Committed)
+			},
+		}, {
+			label: "every transaction is rolled back when the timeout expires",
+			txFunc: func(txs []*sql.Tx) error {
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				for _, mock := range mocks {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				}
+				err := DoAllContext(context.Background(), dbs, txFunc, WithTimeout(50*time.Millisecond))
+				require.ErrorIs(t, err, ErrCanceled)
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				for _, db := range dbs {
+					require.Eventually(t, func() bool { return db.Stats().InUse == 0 }, time.Second, time.Millisecond)
+				}
+			},
+		}, {
+			label: "callbacks run for the transactions that committed or rolled back",
+			txFunc: func(txs []*sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, dbs []*sql.DB, mocks []sqlmock.Sqlmock, txFunc func(txs []*sql.Tx) error) {
+				mocks[0].ExpectBegin()
+				mocks[0].ExpectCommit()
+				mocks[1].ExpectBegin()
+				mocks[1].ExpectCommit().WillReturnError(assert.AnError)
+				r := &recorder{}
+				require.Error(t, DoAllContext(context.Background(), dbs, func(txs []*sql.Tx) error {
+					for i, tx := range txs {
+						require.NoError(t, AfterCommit(tx, r.callback(fmt.Sprintf("commit %d", i), nil)))
+						require.NoError(t, AfterRollback(tx, r.callback(fmt.Sprintf("rollback %d", i), nil)))
+					}
+					return txFunc(txs)
+				}))
+				assert.Equal(t, []string{"commit 0", "rollback 1"}, r.ran)
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			var (
+				dbs   []*sql.DB
+				mocks []sqlmock.Sqlmock
+			)
+			for i := 0; i < 2; i++ {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				defer db.Close()
+				dbs, mocks = append(dbs, db), append(mocks, mock)
+			}
+
+			tc.check(t, dbs, mocks, tc.txFunc)
+			for _, mock := range mocks {
+				assert.NoError(t, mock.ExpectationsWereMet())
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package transact
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrNotInTransaction is returned by AfterCommit and AfterRollback when tx is not a
+	// transaction currently being run by transact.
+	ErrNotInTransaction = errors.New("transact: not in a transaction")
+	// ErrCallbackPanic wraps any panic recovered from an AfterCommit or AfterRollback callback.
+	ErrCallbackPanic = errors.New("transact: callback panicked")
+)
+
+// registry maps every transaction being run by transact to its callbacks.
+var registry sync.Map
+
+// txUnwrapper is implemented by adapter transactions, such as the one in pgxtx, that wrap the
+// value handed to the txFunc. Callbacks are registered against the unwrapped value.
+type txUnwrapper interface {
+	UnwrapTx() any
+}
+
+// callbacks holds the functions queued against a single transaction.
+type callbacks struct {
+	key           any
+	mu            sync.Mutex
+	afterCommit   []func() error
+	afterRollback []func() error
+}
+
+// register starts queueing callbacks for tx. An unwrapped value that cannot be used as a map key
+// falls back to tx itself.
+func register[T Tx](tx T) *callbacks {
+	var key any = tx
+	if u, ok := any(tx).(txUnwrapper); ok {
+		if unwrapped := u.UnwrapTx(); isComparable(unwrapped) {
+			key = unwrapped
+		}
+	}
+
+	cbs := &callbacks{key: key}
+	registry.Store(key, cbs)
+	return cbs
+}
+
+// isComparable reports whether v can be used as a registry key without panicking.
+func isComparable(v any) bool {
+	return v != nil && reflect.ValueOf(v).Comparable()
+}
+
+// unregister stops queueing callbacks, after which AfterCommit and AfterRollback return
+// ErrNotInTransaction.
+func (c *callbacks) unregister() {
+	registry.Delete(c.key)
+}
+
+// AfterCommit queues fn to be run once tx has been successfully committed. Callbacks run in the
+// order they were queued, outside of the transaction, and are discarded if the transaction is
+// rolled back. Any error or panic from fn is reported to Hooks.OnCallbackError.
+//
+// tx must be the value handed to the txFunc by transact.
+func AfterCommit(tx any, fn func() error) error {
+	if !isComparable(tx) {
+		return ErrNotInTransaction
+	}
+
+	v, ok := registry.Load(tx)
+	if !ok {
+		return ErrNotInTransaction
+	}
+
+	c := v.(*callbacks)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.afterCommit = append(c.afterCommit, fn)
+	return nil
+}
+
+// AfterRollback queues fn to be run once tx has been rolled back for any reason, including a
+// panic or a failed commit. It otherwise behaves like AfterCommit.
+func AfterRollback(tx any, fn func() error) error {
+	if !isComparable(tx) {
+		return ErrNotInTransaction
+	}
+
+	v, ok := registry.Load(tx)
+	if !ok {
+		return ErrNotInTransaction
+	}
+
+	c := v.(*callbacks)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.afterRollback = append(c.afterRollback, fn)
+	return nil
+}
+
+// runAfterCommit runs the queued AfterCommit callbacks.
+func (c *callbacks) runAfterCommit(hooks Hooks) {
+	c.mu.Lock()
+	fns := c.afterCommit
+	c.mu.Unlock()
+	runCallbacks(fns, hooks)
+}
+
+// runAfterRollback runs the queued AfterRollback callbacks.
+func (c *callbacks) runAfterRollback(hooks Hooks) {
+	c.mu.Lock()
+	fns := c.afterRollback
+	c.mu.Unlock()
+	runCallbacks(fns, hooks)
+}
+
+// runCallbacks runs every fn, reporting each failure without stopping the others.
+func runCallbacks(fns []func() error, hooks Hooks) {
+	for _, fn := range fns {
+		if err := runCallback(fn); err != nil {
+			hooks.callbackError(err)
+		}
+	}
+}
+
+// runCallback runs fn, converting any panic to an error wrapping ErrCallbackPanic.
+func runCallback(fn func() error) (err error) {
+	defer func() {
+		if pErr := recover(); pErr != nil {
+			err = fmt.Errorf("%w: %v", ErrCallbackPanic, pErr)
+		}
+	}()
+
+	return fn()
+}
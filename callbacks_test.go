@@ -0,0 +1,190 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records which callbacks ran and which errors were reported.
+type recorder struct {
+	ran    []string
+	errors []error
+}
+
+func (r *recorder) callback(name string, err error) func() error {
+	return func() error {
+		r.ran = append(r.ran, name)
+		return err
+	}
+}
+
+func (r *recorder) hooks() Hooks {
+	return Hooks{OnCallbackError: func(err error) { r.errors = append(r.errors, err) }}
+}
+
+func TestCallbacks(t *testing.T) {
+	testCases := []struct {
+		label string
+		check func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder)
+	}{
+		{
+			label: "after commit callbacks run in order on commit",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, DoContext(context.Background(), db, func(tx *sql.Tx) error {
+					require.NoError(t, AfterCommit(tx, r.callback("commit 1", nil)))
+					require.NoError(t, AfterCommit(tx, r.callback("commit 2", nil)))
+					require.NoError(t, AfterRollback(tx, r.callback("rollback", nil)))
+					return nil
+				}, WithHooks(r.hooks())))
+				assert.Equal(t, []string{"commit 1", "commit 2"}, r.ran)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "after rollback callbacks run on any error",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Error(t, DoContext(context.Background(), db, func(tx *sql.Tx) error {
+					require.NoError(t, AfterCommit(tx, r.callback("commit", nil)))
+					require.NoError(t, AfterRollback(tx, r.callback("rollback", nil)))
+					return assert.AnError
+				}, WithHooks(r.hooks())))
+				assert.Equal(t, []string{"rollback"}, r.ran)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "after rollback callbacks run on any panic",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Panics(t, func() {
+					DoContext(context.Background(), db, func(tx *sql.Tx) error {
+						require.NoError(t, AfterCommit(tx, r.callback("commit", nil)))
+						require.NoError(t, AfterRollback(tx, r.callback("rollback", nil)))
+						panic(assert.AnError)
+					}, WithHooks(r.hooks()))
+				})
+				assert.Equal(t, []string{"rollback"}, r.ran)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "after rollback callbacks run on a failed commit",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder) {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(assert.AnError)
+				require.Error(t, DoContext(context.Background(), db, func(tx *sql.Tx) error {
+					require.NoError(t, AfterCommit(tx, r.callback("commit", nil)))
+					require.NoError(t, AfterRollback(tx, r.callback("rollback", nil)))
+					return nil
+				}, WithHooks(r.hooks())))
+				assert.Equal(t, []string{"rollback"}, r.ran)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "callback errors and panics are reported to the hooks",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, DoContext(context.Background(), db, func(tx *sql.Tx) error {
+					require.NoError(t, AfterCommit(tx, r.callback("error", assert.AnError)))
+					require.NoError(t, AfterCommit(tx, func() error { panic("boom") }))
+					require.NoError(t, AfterCommit(tx, r.callback("after", nil)))
+					return nil
+				}, WithHooks(r.hooks())))
+				assert.Equal(t, []string{"error", "after"}, r.ran)
+				require.Len(t, r.errors, 2)
+				assert.ErrorIs(t, r.errors[0], assert.AnError)
+				assert.ErrorIs(t, r.errors[1], ErrCallbackPanic)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "an error is returned outside of a transaction",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, r *recorder) {
+				var finished *sql.Tx
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, DoContext(context.Background(), db, func(tx *sql.Tx) error {
+					finished = tx
+					return nil
+				}))
+				assert.Equal(t, ErrNotInTransaction, AfterCommit(finished, r.callback("commit", nil)))
+				assert.Equal(t, ErrNotInTransaction, AfterRollback(finished, r.callback("rollback", nil)))
+				assert.Empty(t, r.ran)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.check(t, db, mock, &recorder{})
+		})
+	}
+}
+
+// unwrappingTx is a Tx that wraps an arbitrary value, like the adapter in pgxtx.
+type unwrappingTx struct {
+	wrapped any
+}
+
+func (u *unwrappingTx) Commit() error   { return nil }
+func (u *unwrappingTx) Rollback() error { return nil }
+func (u *unwrappingTx) UnwrapTx() any   { return u.wrapped }
+
+func TestRegister(t *testing.T) {
+	testCases := []struct {
+		label string
+		check func(t *testing.T)
+	}{
+		{
+			label: "callbacks are registered against the unwrapped value",
+			check: func(t *testing.T) {
+				wrapped := &struct{ id int }{}
+				cbs := register(&unwrappingTx{wrapped: wrapped})
+				defer cbs.unregister()
+				assert.NoError(t, AfterCommit(wrapped, func() error { return nil }))
+			},
+		}, {
+			label: "a non-comparable unwrapped value falls back to the tx",
+			check: func(t *testing.T) {
+				tx := &unwrappingTx{wrapped: []int{1}}
+				cbs := register(tx)
+				defer cbs.unregister()
+				assert.NoError(t, AfterCommit(tx, func() error { return nil }))
+			},
+		}, {
+			label: "an error is returned for a non-comparable or nil tx",
+			check: func(t *testing.T) {
+				assert.Equal(t, ErrNotInTransaction, AfterCommit([]int{1}, func() error { return nil }))
+				assert.Equal(t, ErrNotInTransaction, AfterRollback(map[string]int{}, func() error { return nil }))
+				assert.Equal(t, ErrNotInTransaction, AfterCommit(nil, func() error { return nil }))
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			tc.check(t)
+		})
+	}
+}
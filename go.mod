@@ -1,8 +1,14 @@
 module github.com/kpurdon/transact
 
+go 1.21
+
 require (
 	github.com/data-dog/go-sqlmock v1.3.0
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.2.2
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
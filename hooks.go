@@ -0,0 +1,25 @@
+package transact
+
+// Hooks are functions called by transact at points in a transaction's lifecycle. Any nil hook is
+// skipped.
+type Hooks struct {
+	// OnCallbackError is called with the error returned by, or the panic recovered from, an
+	// AfterCommit or AfterRollback callback.
+	OnCallbackError func(error)
+	// OnReport is called with the TxReport of the transaction once it, and its callbacks, have
+	// finished. See NewJSONSink and NewNDJSONSink for archiving reports.
+	OnReport func(TxReport)
+}
+
+// WithHooks sets the hooks called for the transaction.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+func (h Hooks) callbackError(err error) {
+	if h.OnCallbackError != nil {
+		h.OnCallbackError(err)
+	}
+}
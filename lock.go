@@ -0,0 +1,88 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLockNotAcquired is returned by DoWithLockContext when the named lock could not be acquired
+// before the timeout expired.
+var ErrLockNotAcquired = errors.New("transact: lock not acquired")
+
+// ErrLockNotReleased wraps any error releasing the named lock after the transaction has finished.
+var ErrLockNotReleased = errors.New("transact: lock not released")
+
+// DoWithLockContext executes the given txFunc inside of a new transaction while holding the named
+// MySQL user-level lock (GET_LOCK). The lock and the transaction are pinned to the same pooled
+// connection, and the lock is always released (RELEASE_LOCK) after the transaction is committed
+// or rolled back, including when txFunc panics. The timeout is rounded up to whole seconds, a
+// timeout of zero does not wait and a negative timeout waits for the lock forever.
+//
+// If the lock cannot be released the connection is discarded from the pool instead of being
+// reused, which causes MySQL to release the lock when the session ends.
+func DoWithLockContext(ctx context.Context, db *sql.DB, name string, timeout time.Duration, txFunc func(*sql.Tx) error, opts ...Option) (err error) {
+	if o := newOptions(opts); o.err != nil {
+		return o.err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var acquired sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, lockTimeout(timeout)).Scan(&acquired)
+	if err != nil {
+		return
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return ErrLockNotAcquired
+	}
+
+	defer func() {
+		rErr := releaseLock(conn, name)
+		if rErr == nil {
+			return
+		}
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		err = errors.Join(err, rErr)
+	}()
+
+	return DoContext(ctx, conn, txFunc, opts...)
+}
+
+// DoWithLock executes the given txFunc inside of a new transaction while holding the named MySQL
+// user-level lock. See DoWithLockContext for details.
+func DoWithLock(db *sql.DB, name string, timeout time.Duration, txFunc func(*sql.Tx) error, opts ...Option) (err error) {
+	return DoWithLockContext(context.Background(), db, name, timeout, txFunc, opts...)
+}
+
+// releaseLock releases the named lock held by conn, wrapping any error with ErrLockNotReleased. A
+// lock that was not held by this session (0) or does not exist (NULL) means the lock was lost and
+// is reported as an error.
+func releaseLock(conn *sql.Conn, name string) error {
+	// the lock must be released even if ctx has been cancelled
+	var released sql.NullInt64
+	err := conn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", name).Scan(&released)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLockNotReleased, err)
+	}
+	if !released.Valid || released.Int64 != 1 {
+		return fmt.Errorf("%w: lock %q was not held by the connection", ErrLockNotReleased, name)
+	}
+	return nil
+}
+
+// lockTimeout converts timeout to the whole seconds expected by GET_LOCK, rounding up so that a
+// sub-second timeout still waits.
+func lockTimeout(timeout time.Duration) int64 {
+	if timeout < 0 {
+		return -1
+	}
+	return int64((timeout + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,150 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoWithLockContext(t *testing.T) {
+	testCases := []struct {
+		label  string
+		txFunc func(tx *sql.Tx) error
+		check  func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error)
+	}{
+		{
+			label: "the lock is released and no error is returned on any success",
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", 5).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT RELEASE_LOCK").WithArgs("lock").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+				require.NoError(t, DoWithLockContext(context.Background(), db, "lock", 5*time.Second, txFunc))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the lock is released and an error is returned on any error",
+			txFunc: func(tx *sql.Tx) error {
+				return assert.AnError
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", 5).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT RELEASE_LOCK").WithArgs("lock").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+				require.EqualError(t, DoWithLockContext(context.Background(), db, "lock", 5*time.Second, txFunc), assert.AnError.Error())
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the lock is released and a panic is raised on any panic",
+			txFunc: func(tx *sql.Tx) error {
+				panic(assert.AnError)
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", -1).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT RELEASE_LOCK").WithArgs("lock").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+				require.Panics(t, func() { DoWithLockContext(context.Background(), db, "lock", -1, txFunc) })
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "an error is returned and no transaction is started when the lock times out",
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+				require.Equal(t, ErrLockNotAcquired, DoWithLockContext(context.Background(), db, "lock", 0, txFunc))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a release error is returned when the transaction succeeds",
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT RELEASE_LOCK").WithArgs("lock").
+					WillReturnError(assert.AnError)
+				err := DoWithLockContext(context.Background(), db, "lock", time.Second, txFunc)
+				require.ErrorIs(t, err, ErrLockNotReleased)
+				require.ErrorIs(t, err, assert.AnError)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a release error is returned when the lock was not held",
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT RELEASE_LOCK").WithArgs("lock").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(0))
+				require.ErrorIs(t, DoWithLockContext(context.Background(), db, "lock", time.Second, txFunc), ErrLockNotReleased)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a sub-second timeout is rounded up to a whole second",
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs("lock", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT RELEASE_LOCK").WithArgs("lock").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+				require.NoError(t, DoWithLockContext(context.Background(), db, "lock", 500*time.Millisecond, txFunc))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "an option error is returned before the lock is acquired",
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				opt := func(o *options) { o.err = assert.AnError }
+				require.Equal(t, assert.AnError, DoWithLockContext(context.Background(), db, "lock", time.Second, txFunc, opt))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.check(t, db, mock, tc.txFunc)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+// DirtyReadsEnv is the environment variable that must be set to "1" before
+// WithDirtyReadDiagnostics takes effect.
+const DirtyReadsEnv = "TRANSACT_ALLOW_DIRTY_READS"
+
+// ErrDirtyReadsDisabled is returned when WithDirtyReadDiagnostics is used without DirtyReadsEnv
+// being set, or without a reason.
+var ErrDirtyReadsDisabled = errors.New("transact: dirty read diagnostics are disabled")
+
+// Option configures a single transaction.
+type Option func(*options)
+
+// options holds the configuration built from a list of Option.
+type options struct {
+	txOptions   *sql.TxOptions
+	dirtyRead   string
+	hooks       Hooks
+	concurrency int
+	timeout     time.Duration
+	name        string
+	labels      map[string]string
+	caller      string
+	err         error
+}
+
+// newOptions applies opts on top of the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTimeout limits how long the transaction may run. Once the timeout expires the transaction
+// is rolled back and an error wrapping ErrCanceled, RollbackTimeout and context.DeadlineExceeded is
+// returned. A timeout of zero or less has no effect. The txFunc is only interrupted if the Tx
+// supports it: database/sql rolls a *sql.Tx back right away and pgxtx cancels the statement in
+// flight, but a txFunc that is not waiting on the database keeps running until it returns. See
+// ConcurrentRollbacker.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// context derives the context the transaction runs with from ctx.
+func (o *options) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, o.timeout, RollbackTimeout)
+}
+
+// WithDirtyReadDiagnostics runs the transaction as READ ONLY at the READ UNCOMMITTED isolation
+// level so that in-flight data from other transactions can be inspected while debugging
+// production issues. It is never meant for normal code paths: it only takes effect when the
+// DirtyReadsEnv environment variable is set to "1", a reason must be given, and every use is
+// logged. Otherwise the transaction is not started and ErrDirtyReadsDisabled is returned.
+//
+// Not every database honours READ UNCOMMITTED. Drivers that reject the isolation level return an
+// error from BeginTx, but PostgreSQL accepts it and silently runs the transaction as READ
+// COMMITTED, so no dirty reads are ever seen there. The pgxtx adapter returns an error instead.
+func WithDirtyReadDiagnostics(reason string) Option {
+	return func(o *options) {
+		if reason == "" || os.Getenv(DirtyReadsEnv) != "1" {
+			o.err = ErrDirtyReadsDisabled
+			return
+		}
+		o.txOptions = &sql.TxOptions{Isolation: sql.LevelReadUncommitted, ReadOnly: true}
+		o.dirtyRead = reason
+	}
+}
+
+// logDirtyRead loudly records the use of WithDirtyReadDiagnostics once the transaction has begun.
+func (o *options) logDirtyRead() {
+	if o.dirtyRead == "" {
+		return
+	}
+	log.Printf("transact: WARNING: started READ UNCOMMITTED diagnostic transaction (dirty reads enabled): %s", o.dirtyRead)
+}
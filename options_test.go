@@ -0,0 +1,85 @@
+package transact
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDirtyReadDiagnostics(t *testing.T) {
+	testCases := []struct {
+		label  string
+		env    string
+		reason string
+		check  func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, logs *bytes.Buffer, opt Option)
+	}{
+		{
+			label:  "the transaction runs when enabled with a reason",
+			env:    "1",
+			reason: "debugging stuck orders",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, logs *bytes.Buffer, opt Option) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opt))
+				assert.NoError(t, mock.ExpectationsWereMet())
+
+				o := newOptions([]Option{opt})
+				assert.Equal(t, &sql.TxOptions{Isolation: sql.LevelReadUncommitted, ReadOnly: true}, o.txOptions)
+				assert.Contains(t, logs.String(), "READ UNCOMMITTED diagnostic transaction (dirty reads enabled): debugging stuck orders")
+			},
+		}, {
+			label:  "nothing is logged when the transaction fails to begin",
+			env:    "1",
+			reason: "debugging stuck orders",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, logs *bytes.Buffer, opt Option) {
+				mock.ExpectBegin().WillReturnError(errors.New("isolation level not supported"))
+				require.Error(t, DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opt))
+				assert.NoError(t, mock.ExpectationsWereMet())
+				assert.Empty(t, logs.String())
+			},
+		}, {
+			label:  "an error is returned and no transaction is started when not enabled",
+			env:    "",
+			reason: "debugging stuck orders",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, logs *bytes.Buffer, opt Option) {
+				require.Equal(t, ErrDirtyReadsDisabled, DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opt))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label:  "an error is returned and no transaction is started without a reason",
+			env:    "1",
+			reason: "",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, logs *bytes.Buffer, opt Option) {
+				require.Equal(t, ErrDirtyReadsDisabled, DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opt))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Log(tc.label)
+			t.Setenv(DirtyReadsEnv, tc.env)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			tc.check(t, db, mock, &logs, WithDirtyReadDiagnostics(tc.reason))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package pgxtx adapts transact to github.com/jackc/pgx/v5 so that a txFunc receives a pgx.Tx.
+package pgxtx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kpurdon/transact"
+)
+
+// ErrReadUncommitted is returned when a READ UNCOMMITTED transaction, such as one started with
+// transact.WithDirtyReadDiagnostics, is requested. PostgreSQL would run it as READ COMMITTED.
+var ErrReadUncommitted = errors.New("pgxtx: read uncommitted is not supported by PostgreSQL")
+
+// DB begins new pgx.Tx transactions. It is satisfied by *pgx.Conn and *pgxpool.Pool.
+type DB interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// beginner adapts a DB to a transact.Beginner.
+type beginner struct {
+	db DB
+}
+
+func (b beginner) BeginTx(ctx context.Context, opts *sql.TxOptions) (*tx, error) {
+	txOptions, err := convertTxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pgxTx, err := b.db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tx{ctx: ctx, tx: pgxTx}, nil
+}
+
+// tx adapts a pgx.Tx to a transact.Tx by binding it to the context it was started with.
+type tx struct {
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+// UnwrapTx returns the pgx.Tx handed to the txFunc so that transact.AfterCommit and
+// transact.AfterRollback can be called with it.
+func (t *tx) UnwrapTx() any {
+	return t.tx
+}
+
+func (t *tx) Commit() error {
+	return t.tx.Commit(t.ctx)
+}
+
+// ConcurrentRollback cancels the statement in flight once the context the transaction was started
+// with is done, so that a txFunc that is waiting on the server fails promptly. PostgreSQL aborts
+// the transaction and refuses its later statements until it is rolled back. CancelRequest uses a
+// separate connection, so this is safe while the txFunc is using the pgx.Tx.
+func (t *tx) ConcurrentRollback() error {
+	return t.tx.Conn().PgConn().CancelRequest(context.WithoutCancel(t.ctx))
+}
+
+func (t *tx) Rollback() error {
+	// a rollback must still be attempted after ctx has been cancelled
+	err := t.tx.Rollback(context.WithoutCancel(t.ctx))
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return sql.ErrTxDone
+	}
+	return err
+}
+
+// DoContext executes the given txFunc inside of a new transaction handling all possible rollback
+// and commit scenarios.
+func DoContext(ctx context.Context, db DB, txFunc func(pgx.Tx) error, opts ...transact.Option) (err error) {
+	return transact.DoTxContext[*tx](ctx, beginner{db}, func(t *tx) error {
+		return txFunc(t.tx)
+	}, opts...)
+}
+
+// Do executes the given txFunc inside of a new transaction handling all possible rollback and
+// commit scenarios.
+func Do(db DB, txFunc func(pgx.Tx) error, opts ...transact.Option) (err error) {
+	return DoContext(context.Background(), db, txFunc, opts...)
+}
+
+// convertTxOptions converts database/sql transaction options to their pgx equivalent.
+func convertTxOptions(opts *sql.TxOptions) (pgx.TxOptions, error) {
+	var txOptions pgx.TxOptions
+	if opts == nil {
+		return txOptions, nil
+	}
+
+	switch opts.Isolation {
+	case sql.LevelDefault:
+	case sql.LevelReadUncommitted:
+		// PostgreSQL silently runs READ UNCOMMITTED as READ COMMITTED
+		return txOptions, ErrReadUncommitted
+	case sql.LevelReadCommitted:
+		txOptions.IsoLevel = pgx.ReadCommitted
+	case sql.LevelRepeatableRead, sql.LevelSnapshot:
+		txOptions.IsoLevel = pgx.RepeatableRead
+	case sql.LevelSerializable:
+		txOptions.IsoLevel = pgx.Serializable
+	default:
+		return txOptions, fmt.Errorf("pgxtx: unsupported isolation level: %v", opts.Isolation)
+	}
+
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	return txOptions, nil
+}
@@ -0,0 +1,325 @@
+package pgxtx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/kpurdon/transact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx is a pgx.Tx that records how it was finished.
+type fakeTx struct {
+	pgx.Tx
+	committed   bool
+	rolledBack  bool
+	rollbackErr error
+}
+
+func (f *fakeTx) Commit(ctx context.Context) error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeTx) Rollback(ctx context.Context) error {
+	f.rolledBack = true
+	return f.rollbackErr
+}
+
+// fakeDB is a DB that begins a single fakeTx.
+type fakeDB struct {
+	tx        *fakeTx
+	txOptions pgx.TxOptions
+}
+
+func (f *fakeDB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	f.txOptions = txOptions
+	return f.tx, nil
+}
+
+// fakeServer is a PostgreSQL server that completes every simple query, except pg_sleep which
+// blocks until a cancel request is received.
+type fakeServer struct {
+	ln       net.Listener
+	cancel   sync.Once
+	canceled chan struct{}
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeServer{ln: ln, canceled: make(chan struct{})}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// connect opens a pgx connection to the server.
+func (s *fakeServer) connect(t *testing.T) *pgx.Conn {
+	conn, err := pgx.Connect(context.Background(), fmt.Sprintf("postgres://test@%s/test?sslmode=disable", s.ln.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close(context.Background()) })
+	return conn
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	backend := pgproto3.NewBackend(conn, conn)
+	msg, err := backend.ReceiveStartupMessage()
+	if err != nil {
+		return
+	}
+	if _, ok := msg.(*pgproto3.CancelRequest); ok {
+		s.cancel.Do(func() { close(s.canceled) })
+		return
+	}
+
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: []byte{0, 0, 0, 1}})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if backend.Flush() != nil {
+		return
+	}
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		query, ok := msg.(*pgproto3.Query)
+		if !ok {
+			return
+		}
+
+		s.mu.Lock()
+		s.queries = append(s.queries, query.String)
+		s.mu.Unlock()
+
+		switch {
+		case strings.Contains(query.String, "pg_sleep"):
+			select {
+			case <-s.canceled:
+			case <-time.After(5 * time.Second):
+			}
+			backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "57014", Message: "canceling statement due to user request"})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'E'})
+		default:
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(strings.ToUpper(query.String))})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'T'})
+		}
+		if backend.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) seen() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func TestDo(t *testing.T) {
+	testCases := []struct {
+		label  string
+		txFunc func(tx pgx.Tx) error
+		check  func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error)
+	}{
+		{
+			label: "an error is returned on any error",
+			txFunc: func(tx pgx.Tx) error {
+				return assert.AnError
+			},
+			check: func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error) {
+				require.EqualError(t, Do(db, txFunc), assert.AnError.Error())
+				assert.False(t, db.tx.committed)
+				assert.True(t, db.tx.rolledBack)
+			},
+		}, {
+			label: "a panic is raised on any panic",
+			txFunc: func(tx pgx.Tx) error {
+				panic(assert.AnError)
+			},
+			check: func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error) {
+				require.Panics(t, func() { Do(db, txFunc) })
+				assert.False(t, db.tx.committed)
+				assert.True(t, db.tx.rolledBack)
+			},
+		}, {
+			label: "no error is returned on any success",
+			txFunc: func(tx pgx.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error) {
+				require.NoError(t, Do(db, txFunc))
+				assert.True(t, db.tx.committed)
+				assert.False(t, db.tx.rolledBack)
+			},
+		}, {
+			label: "a closed transaction is not a rollback error",
+			txFunc: func(tx pgx.Tx) error {
+				tx.(*fakeTx).rollbackErr = pgx.ErrTxClosed
+				return assert.AnError
+			},
+			check: func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error) {
+				require.Equal(t, assert.AnError, Do(db, txFunc))
+				assert.True(t, db.tx.rolledBack)
+			},
+		}, {
+			label: "callbacks can be queued against the pgx.Tx",
+			txFunc: func(tx pgx.Tx) error {
+				return transact.AfterCommit(tx, func() error { panic("boom") })
+			},
+			check: func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error) {
+				var errs []error
+				hooks := transact.Hooks{OnCallbackError: func(err error) { errs = append(errs, err) }}
+				require.NoError(t, Do(db, txFunc, transact.WithHooks(hooks)))
+				require.Len(t, errs, 1)
+				assert.ErrorIs(t, errs[0], transact.ErrCallbackPanic)
+			},
+		}, {
+			label: "the txFunc receives the pgx.Tx",
+			txFunc: func(tx pgx.Tx) error {
+				if _, ok := tx.(*fakeTx); !ok {
+					return assert.AnError
+				}
+				return nil
+			},
+			check: func(t *testing.T, db *fakeDB, txFunc func(tx pgx.Tx) error) {
+				require.NoError(t, Do(db, txFunc))
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			tc.check(t, &fakeDB{tx: &fakeTx{}}, tc.txFunc)
+		})
+	}
+}
+
+func TestConcurrentRollback(t *testing.T) {
+	testCases := []struct {
+		label  string
+		txFunc func(tx pgx.Tx) error
+		check  func(t *testing.T, s *fakeServer, err error, elapsed time.Duration)
+	}{
+		{
+			label: "the statement in flight is canceled once the timeout expires",
+			txFunc: func(tx pgx.Tx) error {
+				_, err := tx.Exec(context.Background(), "select pg_sleep(10)")
+				return err
+			},
+			check: func(t *testing.T, s *fakeServer, err error, elapsed time.Duration) {
+				require.ErrorIs(t, err, transact.ErrCanceled)
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
+				assert.Equal(t, "57014", pgErr.Code)
+				assert.Less(t, elapsed, time.Second)
+				assert.Equal(t, []string{"begin", "select pg_sleep(10)", "rollback"}, s.seen())
+			},
+		}, {
+			label: "a txFunc between statements is rolled back once it returns",
+			txFunc: func(tx pgx.Tx) error {
+				time.Sleep(50 * time.Millisecond)
+				_, err := tx.Exec(context.Background(), "select 1")
+				return err
+			},
+			check: func(t *testing.T, s *fakeServer, err error, elapsed time.Duration) {
+				require.ErrorIs(t, err, transact.ErrCanceled)
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Equal(t, []string{"begin", "select 1", "rollback"}, s.seen())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			s := newFakeServer(t)
+			conn := s.connect(t)
+
+			start := time.Now()
+			err := Do(conn, tc.txFunc, transact.WithTimeout(20*time.Millisecond))
+			tc.check(t, s, err, time.Since(start))
+		})
+	}
+}
+
+func TestConvertTxOptions(t *testing.T) {
+	testCases := []struct {
+		label string
+		opts  *sql.TxOptions
+		check func(t *testing.T, txOptions pgx.TxOptions, err error)
+	}{
+		{
+			label: "nil options use the defaults",
+			opts:  nil,
+			check: func(t *testing.T, txOptions pgx.TxOptions, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, pgx.TxOptions{}, txOptions)
+			},
+		}, {
+			label: "isolation and read only are converted",
+			opts:  &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true},
+			check: func(t *testing.T, txOptions pgx.TxOptions, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly}, txOptions)
+			},
+		}, {
+			label: "an error is returned for read uncommitted",
+			opts:  &sql.TxOptions{Isolation: sql.LevelReadUncommitted, ReadOnly: true},
+			check: func(t *testing.T, txOptions pgx.TxOptions, err error) {
+				require.ErrorIs(t, err, ErrReadUncommitted)
+			},
+		}, {
+			label: "an error is returned on an unsupported isolation level",
+			opts:  &sql.TxOptions{Isolation: sql.LevelLinearizable},
+			check: func(t *testing.T, txOptions pgx.TxOptions, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			txOptions, err := convertTxOptions(tc.opts)
+			tc.check(t, txOptions, err)
+		})
+	}
+}
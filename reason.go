@@ -0,0 +1,58 @@
+package transact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RollbackReason is found in the error chain, using errors.As, whenever transact itself rolled
+// back a transaction instead of the txFunc returning an error. It lets callers tell policy driven
+// rollbacks apart from genuine database errors.
+type RollbackReason string
+
+const (
+	// RollbackCanceled is used when the context was canceled.
+	RollbackCanceled RollbackReason = "context canceled"
+	// RollbackDeadlineExceeded is used when the deadline of the caller's context was exceeded.
+	RollbackDeadlineExceeded RollbackReason = "caller deadline exceeded"
+	// RollbackTimeout is used when the duration given to WithTimeout was exceeded.
+	RollbackTimeout RollbackReason = "timeout exceeded"
+)
+
+func (r RollbackReason) Error() string {
+	return string(r)
+}
+
+// canceledError wraps ErrCanceled, the RollbackReason and the ctx error, but only describes the
+// reason so that the ctx error is not repeated in the message.
+type canceledError struct {
+	reason RollbackReason
+	err    error
+}
+
+func (e *canceledError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCanceled, e.reason)
+}
+
+func (e *canceledError) Unwrap() []error {
+	return []error{ErrCanceled, e.reason, e.err}
+}
+
+// canceled returns a *canceledError once ctx is done.
+func canceled(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+
+	reason := RollbackCanceled
+	if errors.Is(err, context.DeadlineExceeded) {
+		reason = RollbackDeadlineExceeded
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		errors.As(cause, &reason)
+	}
+
+	return &canceledError{reason: reason, err: err}
+}
@@ -0,0 +1,83 @@
+package transact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanceled(t *testing.T) {
+	testCases := []struct {
+		label string
+		setup func(t *testing.T) (context.Context, context.CancelFunc)
+		check func(t *testing.T, err error)
+	}{
+		{
+			label: "no error is returned while the context is not done",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			check: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		}, {
+			label: "a canceled context is a RollbackCanceled",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			check: func(t *testing.T, err error) {
+				var reason RollbackReason
+				require.True(t, errors.As(err, &reason))
+				assert.Equal(t, RollbackCanceled, reason)
+				assert.EqualError(t, err, "transact: transaction canceled: context canceled")
+				assert.ErrorIs(t, err, ErrCanceled)
+				assert.ErrorIs(t, err, context.Canceled)
+			},
+		}, {
+			label: "an exceeded deadline is a RollbackDeadlineExceeded",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			},
+			check: func(t *testing.T, err error) {
+				var reason RollbackReason
+				require.True(t, errors.As(err, &reason))
+				assert.Equal(t, RollbackDeadlineExceeded, reason)
+				assert.EqualError(t, err, "transact: transaction canceled: caller deadline exceeded")
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		}, {
+			label: "an expired timeout option is a RollbackTimeout",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return newOptions([]Option{WithTimeout(time.Nanosecond)}).context(context.Background())
+			},
+			check: func(t *testing.T, err error) {
+				var reason RollbackReason
+				require.True(t, errors.As(err, &reason))
+				assert.Equal(t, RollbackTimeout, reason)
+				assert.EqualError(t, err, "transact: transaction canceled: timeout exceeded")
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			ctx, cancel := tc.setup(t)
+			defer cancel()
+			time.Sleep(time.Millisecond)
+
+			tc.check(t, canceled(ctx))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package transact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome is how a transaction finished.
+type Outcome string
+
+const (
+	// OutcomeCommitted is used when the transaction was committed.
+	OutcomeCommitted Outcome = "committed"
+	// OutcomeRolledBack is used when the transaction was rolled back because of an error or a
+	// done context.
+	OutcomeRolledBack Outcome = "rolled_back"
+	// OutcomePanicked is used when the transaction was rolled back because the txFunc panicked.
+	OutcomePanicked Outcome = "panicked"
+	// OutcomeBeginFailed is used when the transaction could not be started.
+	OutcomeBeginFailed Outcome = "begin_failed"
+	// OutcomeCommitFailed is used when the transaction could not be committed.
+	OutcomeCommitFailed Outcome = "commit_failed"
+)
+
+// TxTimings are the durations of each phase of a transaction.
+type TxTimings struct {
+	Begin  time.Duration `json:"begin_ns"`
+	Func   time.Duration `json:"func_ns"`
+	Finish time.Duration `json:"finish_ns"`
+	Total  time.Duration `json:"total_ns"`
+}
+
+// TxReport is a machine readable summary of a single transaction, passed to Hooks.OnReport once
+// the transaction and its callbacks have finished.
+type TxReport struct {
+	Name    string            `json:"name,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Caller  string            `json:"caller,omitempty"`
+	Start   time.Time         `json:"start"`
+	Timings TxTimings         `json:"timings"`
+	Outcome Outcome           `json:"outcome"`
+	Error   string            `json:"error,omitempty"`
+	Err     error             `json:"-"`
+}
+
+// withCaller sets the caller used in the TxReport, for transactions started from a goroutine
+// that has no frames outside of transact.
+func withCaller(caller string) Option {
+	return func(o *options) {
+		o.caller = caller
+	}
+}
+
+// WithName sets the name of the transaction used in its TxReport.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithLabels sets the labels of the transaction used in its TxReport.
+func WithLabels(labels map[string]string) Option {
+	return func(o *options) {
+		o.labels = labels
+	}
+}
+
+// NewJSONSink returns a Hooks.OnReport hook that writes every TxReport to w as an indented JSON
+// document. Any write error is passed to onError, if it is not nil.
+func NewJSONSink(w io.Writer, onError func(error)) func(TxReport) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return newSink(enc, onError)
+}
+
+// NewNDJSONSink returns a Hooks.OnReport hook that writes every TxReport to w as a single line of
+// JSON (newline delimited JSON). Any write error is passed to onError, if it is not nil.
+func NewNDJSONSink(w io.Writer, onError func(error)) func(TxReport) {
+	return newSink(json.NewEncoder(w), onError)
+}
+
+// newSink serializes writes to enc so that the sink can be shared by concurrent transactions.
+func newSink(enc *json.Encoder, onError func(error)) func(TxReport) {
+	var mu sync.Mutex
+	return func(report TxReport) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(report); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// reporter builds the TxReport of a single transaction. A nil reporter, used when there is no
+// Hooks.OnReport, does nothing.
+type reporter struct {
+	onReport func(TxReport)
+	report   TxReport
+	mark     time.Time
+}
+
+// newReporter starts the TxReport for a transaction being started now.
+func newReporter(o *options) *reporter {
+	if o.hooks.OnReport == nil {
+		return nil
+	}
+
+	c := o.caller
+	if c == "" {
+		c = caller()
+	}
+
+	now := time.Now()
+	return &reporter{
+		onReport: o.hooks.OnReport,
+		mark:     now,
+		report: TxReport{
+			Name:   o.name,
+			Labels: o.labels,
+			Caller: c,
+			Start:  now,
+		},
+	}
+}
+
+// lap returns the time since the previous lap.
+func (r *reporter) lap() time.Duration {
+	now := time.Now()
+	d := now.Sub(r.mark)
+	r.mark = now
+	return d
+}
+
+// begun records the end of the begin phase.
+func (r *reporter) begun() {
+	if r == nil {
+		return
+	}
+	r.report.Timings.Begin = r.lap()
+}
+
+// ran records the end of the txFunc phase.
+func (r *reporter) ran() {
+	if r == nil {
+		return
+	}
+	r.report.Timings.Func = r.lap()
+}
+
+// finished records the end of the commit or rollback phase and the outcome.
+func (r *reporter) finished(outcome Outcome, err error) {
+	if r == nil {
+		return
+	}
+	r.report.Timings.Finish = r.lap()
+	r.report.Timings.Total = r.mark.Sub(r.report.Start)
+	r.report.Outcome = outcome
+	r.report.Err = err
+	if err != nil {
+		r.report.Error = err.Error()
+	}
+}
+
+// send passes the finished TxReport to Hooks.OnReport.
+func (r *reporter) send() {
+	if r == nil {
+		return
+	}
+	r.onReport(r.report)
+}
+
+// pkgPath is the import path of this package, and the prefix of its sub-packages.
+const pkgPath = "github.com/kpurdon/transact"
+
+// caller returns the file:line of the first caller outside of transact and its sub-packages, or
+// "" if the goroutine has no such caller.
+func caller() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			return ""
+		}
+		internal := strings.HasPrefix(frame.Function, pkgPath+".") || strings.HasPrefix(frame.Function, pkgPath+"/")
+		if !internal {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package transact_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/kpurdon/transact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	testCases := []struct {
+		label string
+		check func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport)
+	}{
+		{
+			label: "a committed report is sent on any success",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, transact.DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opts...))
+				require.Len(t, *reports, 1)
+				report := (*reports)[0]
+				assert.Equal(t, transact.OutcomeCommitted, report.Outcome)
+				assert.Equal(t, "orders", report.Name)
+				assert.Equal(t, map[string]string{"team": "payments"}, report.Labels)
+				assert.Contains(t, report.Caller, "report_test.go:")
+				assert.Empty(t, report.Error)
+				assert.False(t, report.Start.IsZero())
+				assert.Equal(t, report.Timings.Begin+report.Timings.Func+report.Timings.Finish, report.Timings.Total)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a rolled back report is sent on any error",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Error(t, transact.DoContext(context.Background(), db, func(tx *sql.Tx) error { return assert.AnError }, opts...))
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomeRolledBack, (*reports)[0].Outcome)
+				assert.Equal(t, assert.AnError.Error(), (*reports)[0].Error)
+				assert.ErrorIs(t, (*reports)[0].Err, assert.AnError)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a panicked report is sent on any panic",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Panics(t, func() {
+					transact.DoContext(context.Background(), db, func(tx *sql.Tx) error { panic("boom") }, opts...)
+				})
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomePanicked, (*reports)[0].Outcome)
+				assert.Equal(t, "panic: boom", (*reports)[0].Error)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a failed rollback is reported on any panic",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectRollback().WillReturnError(assert.AnError)
+				require.Panics(t, func() {
+					transact.DoContext(context.Background(), db, func(tx *sql.Tx) error { panic("boom") }, opts...)
+				})
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomePanicked, (*reports)[0].Outcome)
+				assert.ErrorIs(t, (*reports)[0].Err, transact.ErrRollback)
+				assert.ErrorIs(t, (*reports)[0].Err, assert.AnError)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a failed rollback of any transaction of DoAllContext is reported on any panic",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectRollback().WillReturnError(assert.AnError)
+				require.Panics(t, func() {
+					transact.DoAllContext(context.Background(), []*sql.DB{db, db}, func(txs []*sql.Tx) error { panic("boom") }, opts...)
+				})
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomePanicked, (*reports)[0].Outcome)
+				assert.ErrorIs(t, (*reports)[0].Err, transact.ErrRollback)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a begin failed report is sent when the transaction cannot be started",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin().WillReturnError(assert.AnError)
+				require.Error(t, transact.DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opts...))
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomeBeginFailed, (*reports)[0].Outcome)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a commit failed report is sent when the transaction cannot be committed",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(assert.AnError)
+				require.Error(t, transact.DoContext(context.Background(), db, func(tx *sql.Tx) error { return nil }, opts...))
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomeCommitFailed, (*reports)[0].Outcome)
+				assert.ErrorIs(t, (*reports)[0].Err, transact.ErrCommit)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the report is sent after the callbacks have run",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, transact.DoContext(context.Background(), db, func(tx *sql.Tx) error {
+					return transact.AfterCommit(tx, func() error {
+						assert.Empty(t, *reports)
+						return nil
+					})
+				}, opts...))
+				require.Len(t, *reports, 1)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a single report covers every transaction of DoAllContext",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectCommit()
+				require.NoError(t, transact.DoAllContext(context.Background(), []*sql.DB{db, db}, func(txs []*sql.Tx) error { return nil }, opts...))
+				require.Len(t, *reports, 1)
+				assert.Equal(t, transact.OutcomeCommitted, (*reports)[0].Outcome)
+				assert.Contains(t, (*reports)[0].Caller, "report_test.go:")
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the caller of DoValues is reported for every transaction",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, opts []transact.Option, reports *[]transact.TxReport) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				success := func(tx *sql.Tx) (int, error) { return 1, nil }
+				transact.DoValues(db, []func(*sql.Tx) (int, error){success, success}, opts...)
+				require.Len(t, *reports, 2)
+				for _, report := range *reports {
+					assert.Contains(t, report.Caller, "report_test.go:")
+				}
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			var reports []transact.TxReport
+			opts := []transact.Option{
+				transact.WithName("orders"),
+				transact.WithLabels(map[string]string{"team": "payments"}),
+				transact.WithHooks(transact.Hooks{OnReport: func(report transact.TxReport) { reports = append(reports, report) }}),
+			}
+
+			tc.check(t, db, mock, opts, &reports)
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	reports := []transact.TxReport{
+		{Name: "first", Outcome: transact.OutcomeCommitted},
+		{Name: "second", Outcome: transact.OutcomeRolledBack, Error: "failed"},
+	}
+
+	testCases := []struct {
+		label string
+		sink  func(w *bytes.Buffer) func(transact.TxReport)
+		check func(t *testing.T, out string)
+	}{
+		{
+			label: "the NDJSON sink writes one line per report",
+			sink: func(w *bytes.Buffer) func(transact.TxReport) {
+				return transact.NewNDJSONSink(w, nil)
+			},
+			check: func(t *testing.T, out string) {
+				lines := strings.Split(strings.TrimSpace(out), "\n")
+				require.Len(t, lines, 2)
+				for i, line := range lines {
+					var report transact.TxReport
+					require.NoError(t, json.Unmarshal([]byte(line), &report))
+					assert.Equal(t, reports[i].Name, report.Name)
+					assert.Equal(t, reports[i].Outcome, report.Outcome)
+					assert.Equal(t, reports[i].Error, report.Error)
+				}
+			},
+		}, {
+			label: "the JSON sink writes one indented document per report",
+			sink: func(w *bytes.Buffer) func(transact.TxReport) {
+				return transact.NewJSONSink(w, nil)
+			},
+			check: func(t *testing.T, out string) {
+				dec := json.NewDecoder(strings.NewReader(out))
+				for i := range reports {
+					var report transact.TxReport
+					require.NoError(t, dec.Decode(&report))
+					assert.Equal(t, reports[i].Name, report.Name)
+				}
+				assert.False(t, dec.More())
+				assert.Contains(t, out, "\n  \"name\": \"first\"")
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			var out bytes.Buffer
+			sink := tc.sink(&out)
+			for _, report := range reports {
+				sink(report)
+			}
+
+			tc.check(t, out.String())
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package sqlxtx adapts transact to github.com/jmoiron/sqlx so that a txFunc receives a *sqlx.Tx.
+package sqlxtx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kpurdon/transact"
+)
+
+// DB begins new *sqlx.Tx transactions. It is satisfied by *sqlx.DB and *sqlx.Conn.
+type DB interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// beginner adapts a DB to a transact.Beginner.
+type beginner struct {
+	db DB
+}
+
+func (b beginner) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return b.db.BeginTxx(ctx, opts)
+}
+
+// DoContext executes the given txFunc inside of a new transaction handling all possible rollback
+// and commit scenarios.
+func DoContext(ctx context.Context, db DB, txFunc func(*sqlx.Tx) error, opts ...transact.Option) (err error) {
+	return transact.DoTxContext[*sqlx.Tx](ctx, beginner{db}, txFunc, opts...)
+}
+
+// Do executes the given txFunc inside of a new transaction handling all possible rollback and
+// commit scenarios.
+func Do(db DB, txFunc func(*sqlx.Tx) error, opts ...transact.Option) (err error) {
+	return DoContext(context.Background(), db, txFunc, opts...)
+}
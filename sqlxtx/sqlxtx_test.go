@@ -0,0 +1,68 @@
+package sqlxtx
+
+import (
+	"fmt"
+	"testing"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	testCases := []struct {
+		label  string
+		txFunc func(tx *sqlx.Tx) error
+		check  func(t *testing.T, db *sqlx.DB, mock sqlmock.Sqlmock, txFunc func(tx *sqlx.Tx) error)
+	}{
+		{
+			label: "an error is returned on any error",
+			txFunc: func(tx *sqlx.Tx) error {
+				return assert.AnError
+			},
+			check: func(t *testing.T, db *sqlx.DB, mock sqlmock.Sqlmock, txFunc func(tx *sqlx.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.EqualError(t, Do(db, txFunc), assert.AnError.Error())
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a panic is raised on any panic",
+			txFunc: func(tx *sqlx.Tx) error {
+				panic(assert.AnError)
+			},
+			check: func(t *testing.T, db *sqlx.DB, mock sqlmock.Sqlmock, txFunc func(tx *sqlx.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Panics(t, func() { Do(db, txFunc) })
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "no error is returned on any success",
+			txFunc: func(tx *sqlx.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, db *sqlx.DB, mock sqlmock.Sqlmock, txFunc func(tx *sqlx.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				require.NoError(t, Do(db, txFunc))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.check(t, sqlx.NewDb(db, "sqlmock"), mock, tc.txFunc)
+		})
+	}
+}
@@ -5,27 +5,130 @@ package transact
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 )
 
-// DoContext executes the given txFunc inside of a new transaction handling all possible rollback
-// and commit scenarios.
-func DoContext(ctx context.Context, db *sql.DB, txFunc func(*sql.Tx) error) (err error) {
-	tx, err := db.BeginTx(ctx, nil)
+var (
+	// ErrCommit wraps any error returned while committing a transaction.
+	ErrCommit = errors.New("transact: commit failed")
+	// ErrRollback wraps any error returned while rolling back a transaction.
+	ErrRollback = errors.New("transact: rollback failed")
+	// ErrCanceled wraps the RollbackReason and context error when a transaction is rolled back
+	// because its context was canceled or its deadline was exceeded.
+	ErrCanceled = errors.New("transact: transaction canceled")
+)
+
+// Tx is a transaction that can be committed or rolled back, such as *sql.Tx. Implementations must
+// be comparable, and should be pointers, so that AfterCommit and AfterRollback can find the
+// callbacks queued against them.
+type Tx interface {
+	comparable
+	Commit() error
+	Rollback() error
+}
+
+// Beginner begins new transactions of type T. A *sql.DB is a Beginner[*sql.Tx].
+type Beginner[T Tx] interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (T, error)
+}
+
+// ConcurrentRollbacker is implemented by a Tx that can be rolled back, or aborted so that it can
+// only be rolled back, from another goroutine while the txFunc is still using it. transact calls
+// ConcurrentRollback as soon as the context is done instead of waiting for the txFunc to return,
+// and still calls Rollback once it has. Any other Tx is only rolled back once the txFunc returns,
+// which is safe for transactions that are not safe for concurrent use. *sql.Tx does not need it
+// because database/sql already rolls back when the context passed to BeginTx is done. The pgxtx
+// adapter implements it by canceling the statement in flight.
+type ConcurrentRollbacker interface {
+	ConcurrentRollback() error
+}
+
+// DB begins new *sql.Tx transactions. It is satisfied by *sql.DB, *sql.Conn and any type that
+// embeds them, such as *sqlx.DB.
+type DB = Beginner[*sql.Tx]
+
+// DoTxContext executes the given txFunc inside of a new transaction of any type handling all
+// possible rollback and commit scenarios.
+func DoTxContext[T Tx](ctx context.Context, db Beginner[T], txFunc func(T) error, opts ...Option) (err error) {
+	o := newOptions(opts)
+	if o.err != nil {
+		return o.err
+	}
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	r := newReporter(o)
+	tx, err := db.BeginTx(ctx, o.txOptions)
+	r.begun()
 	if err != nil {
+		r.finished(OutcomeBeginFailed, err)
+		r.send()
 		return
 	}
+	o.logDirtyRead()
+
+	return run(ctx, tx, txFunc, o, r)
+}
+
+// DoTx executes the given txFunc inside of a new transaction of any type handling all possible
+// rollback and commit scenarios.
+func DoTx[T Tx](db Beginner[T], txFunc func(T) error, opts ...Option) (err error) {
+	return DoTxContext(context.Background(), db, txFunc, opts...)
+}
 
+// DoContext executes the given txFunc inside of a new transaction handling all possible rollback
+// and commit scenarios.
+func DoContext(ctx context.Context, db DB, txFunc func(*sql.Tx) error, opts ...Option) (err error) {
+	return DoTxContext(ctx, db, txFunc, opts...)
+}
+
+// Do executes the given txFunc inside of a new transaction handling all possible rollback and
+// commit scenarios.
+func Do(db DB, txFunc func(*sql.Tx) error, opts ...Option) (err error) {
+	return DoContext(context.Background(), db, txFunc, opts...)
+}
+
+// run executes txFunc inside of the already started tx, committing on success and rolling back
+// on any error or panic, or once ctx is done. A failed rollback is joined to the txFunc error, or
+// to the reported panic, a failed commit is wrapped with ErrCommit and a done ctx is wrapped with ErrCanceled. Any
+// queued callbacks are run, and the report is sent, once tx has finished.
+func run[T Tx](ctx context.Context, tx T, txFunc func(T) error, o *options, r *reporter) (err error) {
+	cbs := register(tx)
+	stop := watch(ctx, tx)
+	defer r.send()
 	defer func() {
+		stop()
+		cbs.unregister()
+		r.ran()
+
 		if pErr := recover(); pErr != nil {
-			tx.Rollback()
+			r.finished(OutcomePanicked, errors.Join(fmt.Errorf("panic: %v", pErr), rollback(tx)))
+			cbs.runAfterRollback(o.hooks)
 			panic(pErr)
 		}
 
+		if cErr := canceled(ctx); cErr != nil {
+			err = errors.Join(cErr, err)
+		}
+
 		switch err {
 		case nil:
-			err = tx.Commit()
+			if cErr := tx.Commit(); cErr != nil {
+				err = errors.Join(canceled(ctx), fmt.Errorf("%w: %w", ErrCommit, cErr))
+				r.finished(OutcomeCommitFailed, err)
+				cbs.runAfterRollback(o.hooks)
+				return
+			}
+			r.finished(OutcomeCommitted, nil)
+			cbs.runAfterCommit(o.hooks)
 		default:
-			tx.Rollback()
+			if rErr := rollback(tx); rErr != nil {
+				err = errors.Join(err, rErr)
+			}
+			r.finished(OutcomeRolledBack, err)
+			cbs.runAfterRollback(o.hooks)
 		}
 
 	}()
@@ -33,8 +136,33 @@ func DoContext(ctx context.Context, db *sql.DB, txFunc func(*sql.Tx) error) (err
 	return txFunc(tx)
 }
 
-// Do executes the given txFunc inside of a new transaction handling all possible rollback and
-// commit scenarios.
-func Do(db *sql.DB, txFunc func(*sql.Tx) error) (err error) {
-	return DoContext(context.Background(), db, txFunc)
+// watch rolls back tx as soon as ctx is done if tx is a ConcurrentRollbacker. The returned stop
+// function waits for any rollback already started to finish.
+func watch(ctx context.Context, tx any) (stop func()) {
+	cr, ok := tx.(ConcurrentRollbacker)
+	if !ok {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopAfter := context.AfterFunc(ctx, func() {
+		defer close(done)
+		cr.ConcurrentRollback()
+	})
+
+	return func() {
+		if !stopAfter() {
+			<-done
+		}
+	}
+}
+
+// rollback rolls back tx wrapping any error with ErrRollback. A transaction that has already been
+// committed or rolled back (sql.ErrTxDone) is not considered an error.
+func rollback[T Tx](tx T) error {
+	err := tx.Rollback()
+	if err == nil || errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrRollback, err)
 }
@@ -3,8 +3,11 @@ package transact
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	sqlmock "github.com/data-dog/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -60,26 +63,123 @@ func TestDoContext(t *testing.T) {
 				require.NoError(t, DoContext(ctx, db, txFunc))
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
+		}, {
+			label: "a rollback error is joined to the txFunc error",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			txFunc: func(tx *sql.Tx) error {
+				return assert.AnError
+			},
+			check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				rollbackErr := errors.New("rollback")
+				mock.ExpectBegin()
+				mock.ExpectRollback().WillReturnError(rollbackErr)
+				err := DoContext(ctx, db, txFunc)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.ErrorIs(t, err, ErrRollback)
+				assert.ErrorIs(t, err, rollbackErr)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "an already finished transaction is not a rollback error",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			txFunc: func(tx *sql.Tx) error {
+				tx.Rollback()
+				return assert.AnError
+			},
+			check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Equal(t, assert.AnError, DoContext(ctx, db, txFunc))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a commit error is wrapped with ErrCommit",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			txFunc: func(tx *sql.Tx) error {
+				return nil
+			},
+			check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(assert.AnError)
+				err := DoContext(ctx, db, txFunc)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrCommit)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a context cancellation cancels the transaction",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				ctx := context.Background()
+				ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+				return ctx, cancel
+			},
+			txFunc: func(tx *sql.Tx) error {
+				time.Sleep(200 * time.Millisecond) // anything longer than the timeout
+				return nil
+			},
+			check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				err := DoContext(ctx, db, txFunc)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrCanceled)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				require.Eventually(t, func() bool { return db.Stats().InUse == 0 }, time.Second, time.Millisecond)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a context cancellation rolls back without waiting for the txFunc",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			txFunc: func(tx *sql.Tx) error {
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			},
+			check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				ctx, cancel := context.WithCancel(ctx)
+				rolledBack := make(chan struct{})
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				err := DoContext(ctx, db, func(tx *sql.Tx) error {
+					require.NoError(t, AfterRollback(tx, func() error { close(rolledBack); return nil }))
+					cancel()
+					require.Eventually(t, func() bool { return db.Stats().InUse == 0 }, time.Second, time.Millisecond)
+					return txFunc(tx)
+				})
+				assert.ErrorIs(t, err, ErrCanceled)
+				assert.ErrorIs(t, err, context.Canceled)
+				assert.NoError(t, mock.ExpectationsWereMet())
+				<-rolledBack
+			},
+		}, {
+			label: "the timeout option cancels the transaction",
+			setup: func(t *testing.T) (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			txFunc: func(tx *sql.Tx) error {
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			},
+			check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				err := DoContext(ctx, db, txFunc, WithTimeout(50*time.Millisecond))
+				assert.ErrorIs(t, err, ErrCanceled)
+				assert.ErrorIs(t, err, RollbackTimeout)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				require.Eventually(t, func() bool { return db.Stats().InUse == 0 }, time.Second, time.Millisecond)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
 		},
-		// TODO: figure out context cancellation ... maybe just the sqlmock driver
-		// {
-		// 	label: "a context cancellation cancels the transaction",
-		// 	setup: func(t *testing.T) (context.Context, context.CancelFunc) {
-		// 		ctx := context.Background()
-		// 		ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
-		// 		return ctx, cancel
-		// 	},
-		// 	txFunc: func(tx *sql.Tx) error {
-		// 		time.Sleep(10 * time.Second) // anything longer than the timeout
-		// 		return nil
-		// 	},
-		// 	check: func(t *testing.T, ctx context.Context, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) error) {
-		// 		mock.ExpectBegin()
-		// 		mock.ExpectRollback()
-		// 		require.Error(t, DoContext(ctx, db, txFunc))
-		// 		assert.NoError(t, mock.ExpectationsWereMet())
-		// 	},
-		// },
 	}
 
 	for i, tc := range testCases {
@@ -156,3 +256,90 @@ func TestDo(t *testing.T) {
 		})
 	}
 }
+
+// fakeTx is a Tx that records how, and when, it was finished.
+type fakeTx struct {
+	running    atomic.Bool
+	overlapped atomic.Bool
+	rolledBack chan struct{}
+}
+
+func newFakeTx() *fakeTx {
+	return &fakeTx{rolledBack: make(chan struct{}, 2)}
+}
+
+func (f *fakeTx) Commit() error { return nil }
+
+func (f *fakeTx) Rollback() error {
+	if f.running.Load() {
+		f.overlapped.Store(true)
+	}
+	f.rolledBack <- struct{}{}
+	return nil
+}
+
+// concurrentFakeTx is a fakeTx that may be rolled back while the txFunc is running.
+type concurrentFakeTx struct {
+	*fakeTx
+}
+
+func (c concurrentFakeTx) ConcurrentRollback() error {
+	return c.Rollback()
+}
+
+// fakeBeginner begins a single transaction of type T.
+type fakeBeginner[T Tx] struct {
+	tx T
+}
+
+func (f fakeBeginner[T]) BeginTx(ctx context.Context, opts *sql.TxOptions) (T, error) {
+	return f.tx, nil
+}
+
+func TestDoTxContext(t *testing.T) {
+	testCases := []struct {
+		label string
+		check func(t *testing.T, tx *fakeTx)
+	}{
+		{
+			label: "a Tx is not rolled back while the txFunc is running",
+			check: func(t *testing.T, tx *fakeTx) {
+				err := DoTxContext(context.Background(), fakeBeginner[*fakeTx]{tx}, func(tx *fakeTx) error {
+					tx.running.Store(true)
+					defer tx.running.Store(false)
+					time.Sleep(100 * time.Millisecond)
+					return nil
+				}, WithTimeout(10*time.Millisecond))
+				assert.ErrorIs(t, err, RollbackTimeout)
+				assert.False(t, tx.overlapped.Load())
+				assert.Len(t, tx.rolledBack, 1)
+			},
+		}, {
+			label: "a ConcurrentRollbacker is rolled back while the txFunc is running",
+			check: func(t *testing.T, tx *fakeTx) {
+				err := DoTxContext(context.Background(), fakeBeginner[concurrentFakeTx]{concurrentFakeTx{tx}}, func(tx concurrentFakeTx) error {
+					tx.running.Store(true)
+					defer tx.running.Store(false)
+					select {
+					case <-tx.rolledBack:
+					case <-time.After(time.Second):
+						t.Error("the transaction was not rolled back")
+					}
+					return nil
+				}, WithTimeout(10*time.Millisecond))
+				assert.ErrorIs(t, err, RollbackTimeout)
+				assert.True(t, tx.overlapped.Load())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			tc.check(t, newFakeTx())
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Package transacttest provides helpers for isolating integration tests inside of transactions
+// that are always rolled back.
+package transacttest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/kpurdon/transact"
+)
+
+// Run executes fn inside of a new transaction that is always rolled back, even when fn succeeds,
+// so nothing fn writes outlives the test. The transaction is also rolled back when fn panics or
+// stops the test with t.FailNow. The test is failed if the transaction cannot be started or
+// rolled back.
+func Run(t testing.TB, db transact.DB, fn func(tx *sql.Tx)) {
+	t.Helper()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("transacttest: begin: %v", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("transacttest: rollback: %v", err)
+		}
+	}()
+
+	fn(tx)
+}
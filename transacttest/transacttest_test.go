@@ -0,0 +1,69 @@
+package transacttest
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		label string
+		fn    func(tx *sql.Tx)
+		check func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, fn func(tx *sql.Tx))
+	}{
+		{
+			label: "the transaction is rolled back on any success",
+			fn: func(tx *sql.Tx) {
+				tx.Exec("INSERT INTO fixtures")
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, fn func(tx *sql.Tx)) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO fixtures").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectRollback()
+				Run(t, db, fn)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the transaction is rolled back and a panic is raised on any panic",
+			fn: func(tx *sql.Tx) {
+				panic(assert.AnError)
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, fn func(tx *sql.Tx)) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Panics(t, func() { Run(t, db, fn) })
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "an already finished transaction is not a rollback error",
+			fn: func(tx *sql.Tx) {
+				tx.Rollback()
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, fn func(tx *sql.Tx)) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				Run(t, db, fn)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.check(t, db, mock, tc.fn)
+		})
+	}
+}
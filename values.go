@@ -0,0 +1,112 @@
+package transact
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Result is the outcome of a single txFunc run by DoValuesContext.
+type Result[V any] struct {
+	Value V
+	Err   error
+}
+
+// WithConcurrency sets how many transactions DoValuesContext runs at once. The default of 1 runs
+// them one after another. It has no effect on any other function.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// DoValueContext executes the given txFunc inside of a new transaction handling all possible
+// rollback and commit scenarios, returning the value produced by txFunc. The zero value is
+// returned with any error.
+func DoValueContext[V any](ctx context.Context, db DB, txFunc func(*sql.Tx) (V, error), opts ...Option) (V, error) {
+	var value V
+	err := DoContext(ctx, db, func(tx *sql.Tx) (err error) {
+		value, err = txFunc(tx)
+		return
+	}, opts...)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// DoValue executes the given txFunc inside of a new transaction handling all possible rollback and
+// commit scenarios, returning the value produced by txFunc.
+func DoValue[V any](db DB, txFunc func(*sql.Tx) (V, error), opts ...Option) (V, error) {
+	return DoValueContext(context.Background(), db, txFunc, opts...)
+}
+
+// DoValuesContext executes each of the given txFuncs inside of its own transaction, returning a
+// Result for every txFunc in the same order. A failed txFunc only rolls back its own transaction,
+// so the others may still commit. If any txFunc panics no further txFuncs are started and the
+// panic is raised once the running ones have finished.
+func DoValuesContext[V any](ctx context.Context, db DB, txFuncs []func(*sql.Tx) (V, error), opts ...Option) []Result[V] {
+	o := newOptions(opts)
+	concurrency := o.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if o.hooks.OnReport != nil {
+		// the goroutines below have no frames outside of transact to report
+		opts = append(opts[:len(opts):len(opts)], withCaller(caller()))
+	}
+
+	var (
+		results = make([]Result[V], len(txFuncs))
+		sem     = make(chan struct{}, concurrency)
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		pErr    any
+	)
+
+	panicked := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return pErr != nil
+	}
+
+	for i, txFunc := range txFuncs {
+		sem <- struct{}{}
+		if panicked() {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, txFunc func(*sql.Tx) (V, error)) {
+			defer func() {
+				if p := recover(); p != nil {
+					mu.Lock()
+					if pErr == nil {
+						pErr = p
+					}
+					mu.Unlock()
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			value, err := DoValueContext(ctx, db, txFunc, opts...)
+			results[i] = Result[V]{Value: value, Err: err}
+		}(i, txFunc)
+	}
+	wg.Wait()
+
+	if pErr != nil {
+		panic(pErr)
+	}
+
+	return results
+}
+
+// DoValues executes each of the given txFuncs inside of its own transaction, returning a Result
+// for every txFunc in the same order. See DoValuesContext for details.
+func DoValues[V any](db DB, txFuncs []func(*sql.Tx) (V, error), opts ...Option) []Result[V] {
+	return DoValuesContext(context.Background(), db, txFuncs, opts...)
+}
@@ -0,0 +1,165 @@
+package transact
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sqlmock "github.com/data-dog/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoValue(t *testing.T) {
+	testCases := []struct {
+		label  string
+		txFunc func(tx *sql.Tx) (int, error)
+		check  func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) (int, error))
+	}{
+		{
+			label: "the value is returned on any success",
+			txFunc: func(tx *sql.Tx) (int, error) {
+				return 1, nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) (int, error)) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				value, err := DoValue(db, txFunc)
+				require.NoError(t, err)
+				assert.Equal(t, 1, value)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the zero value is returned on any error",
+			txFunc: func(tx *sql.Tx) (int, error) {
+				return 1, assert.AnError
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) (int, error)) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				value, err := DoValue(db, txFunc)
+				require.EqualError(t, err, assert.AnError.Error())
+				assert.Zero(t, value)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "the zero value is returned on a failed commit",
+			txFunc: func(tx *sql.Tx) (int, error) {
+				return 1, nil
+			},
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, txFunc func(tx *sql.Tx) (int, error)) {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(assert.AnError)
+				value, err := DoValue(db, txFunc)
+				require.ErrorIs(t, err, ErrCommit)
+				assert.Zero(t, value)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.check(t, db, mock, tc.txFunc)
+		})
+	}
+}
+
+func TestDoValues(t *testing.T) {
+	success := func(v int) func(tx *sql.Tx) (int, error) {
+		return func(tx *sql.Tx) (int, error) { return v, nil }
+	}
+	failure := func(tx *sql.Tx) (int, error) { return 0, assert.AnError }
+
+	testCases := []struct {
+		label string
+		check func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock)
+	}{
+		{
+			label: "a result is returned for every txFunc in order",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				results := DoValues(db, []func(*sql.Tx) (int, error){success(1), failure, success(3)})
+				require.Len(t, results, 3)
+				assert.Equal(t, Result[int]{Value: 1}, results[0])
+				assert.Equal(t, assert.AnError, results[1].Err)
+				assert.Equal(t, Result[int]{Value: 3}, results[2])
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "no more than the concurrency limit run at once",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				mock.MatchExpectationsInOrder(false)
+
+				var running, peak int32
+				txFuncs := make([]func(*sql.Tx) (int, error), 6)
+				for i := range txFuncs {
+					i := i
+					mock.ExpectBegin()
+					mock.ExpectCommit()
+					txFuncs[i] = func(tx *sql.Tx) (int, error) {
+						n := atomic.AddInt32(&running, 1)
+						defer atomic.AddInt32(&running, -1)
+						for {
+							p := atomic.LoadInt32(&peak)
+							if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+								break
+							}
+						}
+						time.Sleep(10 * time.Millisecond)
+						return i, nil
+					}
+				}
+
+				results := DoValues(db, txFuncs, WithConcurrency(2))
+				for i, result := range results {
+					assert.Equal(t, Result[int]{Value: i}, result)
+				}
+				assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		}, {
+			label: "a panic is raised on any panic",
+			check: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				require.Panics(t, func() {
+					DoValues(db, []func(*sql.Tx) (int, error){
+						func(tx *sql.Tx) (int, error) { panic(assert.AnError) },
+						success(2),
+					})
+				})
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.label)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.check(t, db, mock)
+		})
+	}
+}